@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// processesHandler 回應 /api/processes，列出目前由 supervisord 管理的程式。
+func processesHandler(pm *processManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pm.list())
+	}
+}
+
+// processActionRequest 是 /api/processes/start 與 /api/processes/stop 共用的請求格式。
+type processActionRequest struct {
+	Name string `json:"name"`
+}
+
+// processControlHandler 包出 start/stop 共用的請求解析與錯誤處理，實際動作交給 action。
+func processControlHandler(action func(name string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req processActionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+			http.Error(w, "invalid request body, expected {\"name\":\"...\"}", http.StatusBadRequest)
+			return
+		}
+		if err := action(req.Name); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
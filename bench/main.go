@@ -0,0 +1,202 @@
+// Command bench 透過一個迴路（loopback）WebSocket 連線重播一份日誌檔，
+// 測量批次廣播參數（flush-interval、max-batch-bytes）與 permessage-deflate
+// 對 frames/sec、訊息酬載位元組數與 CPU 時間的影響，藉此佐證主程式的預設值。
+// 位元組數是客戶端收到的訊息內容（解壓縮後）長度，不是壓縮後實際在線路上傳輸的位元組數。
+//
+// 用法：
+//
+//	go run ./bench -lines 200000 -flush-interval 50ms -max-batch-bytes 16384
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func main() {
+	logPath := flag.String("log", "", "要重播的日誌檔路徑，留空則產生一份合成的日誌檔")
+	lines := flag.Int("lines", 200_000, "合成日誌檔的行數（只在 -log 留空時使用）")
+	flushInterval := flag.Duration("flush-interval", 50*time.Millisecond, "批次廣播的最長等待時間")
+	maxBatchBytes := flag.Int("max-batch-bytes", 16*1024, "批次廣播的位元組數上限")
+	noCompress := flag.Bool("no-compress", false, "停用 permessage-deflate")
+	compressLevel := flag.Int("compress-level", 1, "permessage-deflate 的壓縮等級（-2~9）")
+	flag.Parse()
+
+	path := *logPath
+	if path == "" {
+		generated, err := generateLog(*lines)
+		if err != nil {
+			log.Fatalf("bench: failed to generate log file: %v", err)
+		}
+		defer os.Remove(generated)
+		path = generated
+	}
+
+	result, err := run(path, *flushInterval, *maxBatchBytes, !*noCompress, *compressLevel)
+	if err != nil {
+		log.Fatalf("bench: %v", err)
+	}
+	result.Print()
+}
+
+// generateLog 產生一份合成的日誌檔，每行內容與格式盡量貼近真實的應用程式日誌。
+func generateLog(n int) (string, error) {
+	f, err := os.CreateTemp("", "to_view_log-bench-*.log")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(w, "2026-07-30T12:00:%02d.%03dZ [INFO] request completed id=%d status=200 duration_ms=%d\n",
+			i%60, i%1000, i, i%250)
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// result 彙整一次重播量測的結果。
+type result struct {
+	Elapsed    time.Duration
+	Frames     int
+	Bytes      int64
+	CPU        cpuUsage
+	Compressed bool
+}
+
+func (r result) Print() {
+	fmt.Printf("elapsed:        %s\n", r.Elapsed)
+	fmt.Printf("frames:         %d (%.0f frames/sec)\n", r.Frames, float64(r.Frames)/r.Elapsed.Seconds())
+	fmt.Printf("payload bytes:  %d (%.0f bytes/sec)\n", r.Bytes, float64(r.Bytes)/r.Elapsed.Seconds())
+	fmt.Printf("compression:    %v\n", r.Compressed)
+	fmt.Printf("cpu user/sys:   %.3fs / %.3fs\n", r.CPU.UserTime, r.CPU.SysTime)
+}
+
+// run 啟動一個本機的 loopback WebSocket 伺服器，把 path 當成日誌檔重播給單一客戶端，
+// 並統計客戶端實際收到的 frame 數與位元組數。位元組數是 ReadMessage 回傳內容的長度，
+// 也就是解壓縮後的訊息酬載，不含 frame 標頭，也不反映 permessage-deflate 省下的傳輸量。
+func run(path string, flushInterval time.Duration, maxBatchBytes int, compress bool, compressLevel int) (result, error) {
+	upgrader := websocket.Upgrader{EnableCompression: compress}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("bench server: upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		if compress {
+			conn.EnableWriteCompression(true)
+			conn.SetCompressionLevel(compressLevel)
+		}
+		if err := replay(conn, path, flushInterval, maxBatchBytes); err != nil {
+			log.Printf("bench server: replay failed: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	dialer := websocket.Dialer{EnableCompression: compress}
+
+	cpuBefore := readCPUUsage()
+	start := time.Now()
+
+	conn, _, err := dialer.Dial(wsURL, nil)
+	if err != nil {
+		return result{}, fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	var frames int
+	var totalBytes int64
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			break // 伺服器在重播完畢後關閉連線，這裡視為正常結束
+		}
+		frames++
+		totalBytes += int64(len(data))
+	}
+
+	elapsed := time.Since(start)
+	cpuAfter := readCPUUsage()
+
+	return result{
+		Elapsed: elapsed,
+		Frames:  frames,
+		Bytes:   totalBytes,
+		CPU: cpuUsage{
+			UserTime: cpuAfter.UserTime - cpuBefore.UserTime,
+			SysTime:  cpuAfter.SysTime - cpuBefore.SysTime,
+		},
+		Compressed: compress,
+	}, nil
+}
+
+// replay 以批次方式把 path 的每一行送給 conn，批次策略與 fileWorker.run 相同：
+// 累積到 flushInterval 或 maxBatchBytes，兩者先到先送出。
+func replay(conn *websocket.Conn, path string, flushInterval time.Duration, maxBatchBytes int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	lines := make(chan string, 1024)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	var batch []string
+	var batchBytes int
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := conn.WriteMessage(websocket.TextMessage, []byte(strings.Join(batch, "\n")))
+		batch = nil
+		batchBytes = 0
+		return err
+	}
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return flush()
+			}
+			batch = append(batch, line)
+			batchBytes += len(line) + 1
+			if maxBatchBytes > 0 && batchBytes >= maxBatchBytes {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package main
+
+// cpuUsage 是本次量測期間耗用的使用者/系統 CPU 時間（秒）。
+type cpuUsage struct {
+	UserTime float64
+	SysTime  float64
+}
+
+// readCPUUsage 在非 unix 系統上沒有可移植的 rusage 可讀，回傳零值。
+func readCPUUsage() cpuUsage {
+	return cpuUsage{}
+}
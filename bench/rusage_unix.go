@@ -0,0 +1,23 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// cpuUsage 是本次量測期間耗用的使用者/系統 CPU 時間（秒）。
+type cpuUsage struct {
+	UserTime float64
+	SysTime  float64
+}
+
+// readCPUUsage 讀取目前行程的 rusage，僅在 unix 系統上可用。
+func readCPUUsage() cpuUsage {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return cpuUsage{}
+	}
+	return cpuUsage{
+		UserTime: float64(ru.Utime.Sec) + float64(ru.Utime.Usec)/1e6,
+		SysTime:  float64(ru.Stime.Sec) + float64(ru.Stime.Usec)/1e6,
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket" // WebSocket 庫
+)
+
+const (
+	// sendBufSize 是每個客戶端待寫佇列的容量，超過就視為慢速消費者。
+	sendBufSize = 256
+	// writeWait 是單次 WriteMessage/NextWriter 允許花費的最長時間。
+	writeWait = 10 * time.Second
+	// pongWait 是等待客戶端回應 pong 的時間，超過視為連線已死。
+	pongWait = 60 * time.Second
+	// pingPeriod 必須小於 pongWait，才能在逾時前送出下一個 ping。
+	pingPeriod = (pongWait * 9) / 10
+)
+
+// Client 代表一個已經完成 WebSocket 升級的連線，
+// 可能同時訂閱了多個檔案（多個 fileWorker 會持有它的參考）。
+//
+// 所有寫入都先進入 send，再由專屬的 writePump goroutine 逐一送出，
+// 這樣慢速客戶端只會塞滿自己的佇列，不會卡住廣播訊息的 fileWorker。
+type Client struct {
+	hub  *Hub
+	conn *websocket.Conn
+
+	// sendMu 保護 send 與 closed：deliver 在持有這把鎖的情況下才會對 send 做
+	// non-blocking send，evict 也在持有同一把鎖的情況下才會 close(send)，
+	// 這樣兩者永遠不會交錯，避免對已關閉的 channel 送值而 panic。
+	sendMu sync.Mutex
+	send   chan []byte
+	closed bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	closeOnce sync.Once
+
+	mu     sync.RWMutex
+	filter *regexp.Regexp
+	paused bool
+}
+
+func newClient(hub *Hub, conn *websocket.Conn) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Client{
+		hub:    hub,
+		conn:   conn,
+		send:   make(chan []byte, sendBufSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	go c.writePump()
+	return c
+}
+
+// deliver 將伺服器端訊息序列化後放進 send 佇列。
+// 佇列已滿代表客戶端端跟不上，直接以 1011 關閉連線，而不是阻塞呼叫者（通常是 fileWorker）。
+//
+// 一個 Client 可能同時被多個 fileWorker 持有參考（訂閱多個檔案），deliver 因此會被不同
+// goroutine 並行呼叫；send/closed 由 sendMu 保護，確保不會對已經被 evict 關閉的 channel 送值。
+func (c *Client) deliver(v interface{}) {
+	data := mustMarshal(v)
+
+	c.sendMu.Lock()
+	if c.closed {
+		c.sendMu.Unlock()
+		return
+	}
+	select {
+	case c.send <- data:
+		c.sendMu.Unlock()
+	default:
+		c.sendMu.Unlock()
+		log.Printf("client: send buffer full, evicting slow consumer")
+		// evict 會再去鎖 hub.mu 與各 fileWorker.mu，而呼叫者（broadcastBatch、broadcastStats）
+		// 可能正持有它們，因此另開 goroutine 執行，避免呼叫者自己鎖死。
+		go c.evict("slow consumer")
+	}
+}
+
+// deliverLogBatch 是 fileWorker 批次廣播日誌行時使用的入口，會先套用 pause/resume 狀態與 filter regex，
+// 只有通過篩選的行才會合併成一個 frame 送出。歷史回補（attach 當下的 ring buffer snapshot）不經過這裡，
+// 不受之後設定的 filter 影響。
+func (c *Client) deliverLogBatch(file string, lines []string) {
+	c.mu.RLock()
+	paused, filter := c.paused, c.filter
+	c.mu.RUnlock()
+
+	if paused {
+		return
+	}
+
+	kept := lines
+	if filter != nil {
+		kept = kept[:0:0]
+		for _, line := range lines {
+			if filter.MatchString(line) {
+				kept = append(kept, line)
+			}
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+	c.deliver(logMessage{Type: "log", File: file, Line: strings.Join(kept, "\n")})
+}
+
+// setFilter 編譯並套用客戶端送來的 regex；空字串代表取消 filter，收到全部的行。
+func (c *Client) setFilter(pattern string) error {
+	if pattern == "" {
+		c.mu.Lock()
+		c.filter = nil
+		c.mu.Unlock()
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.filter = re
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Client) setPaused(paused bool) {
+	c.mu.Lock()
+	c.paused = paused
+	c.mu.Unlock()
+}
+
+// writePump 是唯一會呼叫 conn.WriteMessage 的 goroutine，
+// 同時負責定期送出 ping 訊框，以及在 ctx 被取消時乾淨地結束，避免 goroutine 洩漏。
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-c.send:
+			if !ok {
+				// send 已被 evict 關閉，關閉訊框已經送出，直接結束。
+				return
+			}
+			if err := c.writeFrame(data); err != nil {
+				return
+			}
+
+			// send 裡每一筆都是獨立的頂層 JSON 文件（"log"、"stats"、"error" ...），
+			// 不能合併進同一個 frame 否則客戶端整個 frame 一起 Unmarshal 會失敗；
+			// 這裡只是把目前已經排隊的訊息逐一送出，而不是等下一輪 select 才處理。
+			pending := len(c.send)
+			for i := 0; i < pending; i++ {
+				if err := c.writeFrame(<-c.send); err != nil {
+					return
+				}
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// writeFrame 把一筆已序列化的訊息當作獨立的 WebSocket text frame 送出。
+func (c *Client) writeFrame(data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// evict 強制關閉這個客戶端的連線：從 hub 取消所有訂閱、送出帶有原因的 1011 關閉訊框，
+// 關閉 send 讓 writePump 結束，並取消 ctx 讓其他仰賴它的 goroutine 一併退出。
+// 使用 sync.Once 確保重複呼叫（例如讀寫兩端同時偵測到問題）是安全的。
+func (c *Client) evict(reason string) {
+	c.closeOnce.Do(func() {
+		c.hub.detachAll(c)
+		c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		closeMsg := websocket.FormatCloseMessage(websocket.CloseInternalServerErr, reason)
+		c.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait))
+
+		c.sendMu.Lock()
+		c.closed = true
+		close(c.send)
+		c.sendMu.Unlock()
+
+		c.cancel()
+	})
+}
+
+// serve 是每個連線的讀取主迴圈：設定讀取逾時與 pong handler 偵測死連線，
+// 讀取客戶端訊息並依 type 分派處理，直到連線關閉或讀取出錯。
+func (c *Client) serve() {
+	defer c.evict("connection closed")
+
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		_, raw, err := c.conn.ReadMessage()
+		if err != nil {
+			log.Printf("client: read error: %v", err)
+			return
+		}
+
+		var msg clientMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			c.deliver(errorMessage{Type: "error", Message: "invalid json"})
+			continue
+		}
+
+		switch msg.Type {
+		case "attach":
+			var attachErr error
+			if msg.Program != "" {
+				attachErr = c.hub.attachProgram(c, msg.Program, msg.Stream)
+			} else {
+				attachErr = c.hub.attach(c, msg.File)
+			}
+			if attachErr != nil {
+				c.deliver(errorMessage{Type: "error", Message: attachErr.Error()})
+			}
+		case "detach":
+			if msg.Program != "" {
+				c.hub.detachProgram(c, msg.Program, msg.Stream)
+			} else {
+				c.hub.detach(c, msg.File)
+			}
+		case "list":
+			c.deliver(c.hub.list())
+		case "filter":
+			if err := c.setFilter(msg.Regex); err != nil {
+				c.deliver(errorMessage{Type: "error", Message: "invalid regex: " + err.Error()})
+			}
+		case "pause":
+			c.setPaused(true)
+		case "resume":
+			c.setPaused(false)
+		default:
+			c.deliver(errorMessage{Type: "error", Message: "unknown message type"})
+		}
+	}
+}
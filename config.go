@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config 集中管理伺服器啟動時的所有可調參數。
+type Config struct {
+	// RootDir 是允許被追蹤的日誌檔案根目錄，所有相對路徑都會以此為基準解析。
+	RootDir string
+	// AllowedGlobs 限制 RootDir 底下哪些檔案可以被列出與追蹤，例如 "*.log"。
+	AllowedGlobs []string
+	// Poll 為 true 時使用輪詢方式追蹤檔案，否則使用 inotify。
+	Poll bool
+	// MaxLineSize 是單行日誌允許的最大位元組數，超過會被截斷。
+	MaxLineSize int
+	// BacklogLines 是新客戶端 attach 時，從環形緩衝區回補的最大行數。
+	BacklogLines int
+	// ListCacheTTL 是 `list` 回應的快取時間，避免頻繁掃描目錄。
+	ListCacheTTL time.Duration
+	// Addr 是 HTTP 服務監聽的位址。
+	Addr string
+
+	// AllowedOrigins 是 WebSocket 升級時允許的來源列表，支援完全比對或 "*.example.com" 萬用字元。
+	// 空列表代表不限制來源（等同舊版行為），僅建議在開發環境使用。
+	AllowedOrigins []string
+
+	// AuthUser/AuthPassHash 是 HTTP Basic Auth 的帳號與密碼的 SHA-256 雜湊（hex），
+	// 兩者皆非空才會啟用驗證。可由環境變數 AUTH_USER/AUTH_PASS_HASH 或 -auth-config 的 YAML 檔載入，
+	// 兩者都有時以 YAML 檔為準。
+	AuthUser     string
+	AuthPassHash string
+
+	// TLSCertFile/TLSKeyFile 同時指定時，伺服器會以 ListenAndServeTLS 啟動，
+	// 客戶端需改用 wss:// 連線。
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// SupervisorURL 是 supervisord RPC2 端點，例如 http://127.0.0.1:9001/RPC2。
+	// 空字串代表不啟用 supervisor 整合。
+	SupervisorURL string
+	// SupervisorRefresh 是重新列舉 supervisord 管理程式的間隔。
+	SupervisorRefresh time.Duration
+
+	// FlushInterval 是 fileWorker 批次廣播的時間上限，與 MaxBatchBytes 兩者先到先觸發 flush。
+	FlushInterval time.Duration
+	// MaxBatchBytes 是單次批次廣播的位元組數上限（估算值），0 代表不以大小觸發。
+	MaxBatchBytes int
+	// NoCompress 為 true 時停用 permessage-deflate，CompressionLevel 才會被忽略。
+	NoCompress bool
+	// CompressionLevel 對應 flate 壓縮等級（-2 ~ 9），只有在 NoCompress 為 false 時生效。
+	CompressionLevel int
+}
+
+// defaultConfig 回傳與舊版行為相容的預設值。
+func defaultConfig() Config {
+	return Config{
+		RootDir:      "/var/log",
+		AllowedGlobs: []string{"*.log"},
+		Poll:         true,
+		MaxLineSize:  64 * 1024,
+		BacklogLines: 200,
+		ListCacheTTL: 5 * time.Second,
+		Addr:         ":1111",
+
+		SupervisorRefresh: 10 * time.Second,
+
+		FlushInterval:    50 * time.Millisecond,
+		MaxBatchBytes:    16 * 1024,
+		CompressionLevel: 1, // flate.BestSpeed：日誌多半是文字，追求延遲優先於壓縮率
+	}
+}
+
+// parseFlags 從命令列旗標建立 Config，未指定的旗標沿用 defaultConfig 的值。
+func parseFlags() Config {
+	cfg := defaultConfig()
+
+	flag.StringVar(&cfg.RootDir, "root", cfg.RootDir, "允許追蹤的日誌根目錄")
+	globs := flag.String("glob", "*.log", "允許追蹤的檔案樣式，以逗號分隔，例如 *.log,*.err")
+	flag.BoolVar(&cfg.Poll, "poll", cfg.Poll, "是否使用輪詢方式追蹤檔案（否則使用 inotify）")
+	flag.IntVar(&cfg.MaxLineSize, "max-line-size", cfg.MaxLineSize, "單行日誌允許的最大位元組數")
+	flag.IntVar(&cfg.BacklogLines, "backlog-lines", cfg.BacklogLines, "新客戶端 attach 時回補的歷史行數")
+	flag.DurationVar(&cfg.ListCacheTTL, "list-cache-ttl", cfg.ListCacheTTL, "list 回應的快取時間")
+	flag.StringVar(&cfg.Addr, "addr", cfg.Addr, "HTTP 服務監聽位址")
+	origins := flag.String("allowed-origins", "", "允許的 WebSocket 來源，以逗號分隔，支援 *.example.com 萬用字元，留空代表不限制")
+	authConfigPath := flag.String("auth-config", "", "包含 Basic Auth 帳號與密碼雜湊的 YAML 設定檔路徑")
+	flag.StringVar(&cfg.TLSCertFile, "tls-cert", "", "TLS 憑證檔路徑，與 -tls-key 同時指定時以 HTTPS/WSS 啟動")
+	flag.StringVar(&cfg.TLSKeyFile, "tls-key", "", "TLS 私鑰檔路徑")
+	flag.StringVar(&cfg.SupervisorURL, "supervisor-url", "", "supervisord 的 XML-RPC 端點，例如 http://127.0.0.1:9001/RPC2，留空代表不啟用")
+	flag.DurationVar(&cfg.SupervisorRefresh, "supervisor-refresh", cfg.SupervisorRefresh, "重新列舉 supervisord 管理程式的間隔")
+	flag.DurationVar(&cfg.FlushInterval, "flush-interval", cfg.FlushInterval, "批次廣播日誌行的最長等待時間")
+	flag.IntVar(&cfg.MaxBatchBytes, "max-batch-bytes", cfg.MaxBatchBytes, "批次廣播日誌行的位元組數上限，達到就提前送出")
+	noCompress := flag.Bool("no-compress", false, "停用 permessage-deflate 壓縮")
+	flag.IntVar(&cfg.CompressionLevel, "compress-level", cfg.CompressionLevel, "permessage-deflate 的壓縮等級（-2~9）")
+	flag.Parse()
+	cfg.NoCompress = *noCompress
+
+	cfg.AllowedGlobs = splitNonEmpty(*globs, ',')
+	cfg.AllowedOrigins = splitNonEmpty(*origins, ',')
+
+	cfg.AuthUser = os.Getenv("AUTH_USER")
+	cfg.AuthPassHash = os.Getenv("AUTH_PASS_HASH")
+	if *authConfigPath != "" {
+		if user, hash, err := loadAuthConfig(*authConfigPath); err != nil {
+			log.Fatalf("failed to load -auth-config %s: %v", *authConfigPath, err)
+		} else {
+			cfg.AuthUser, cfg.AuthPassHash = user, hash
+		}
+	}
+
+	if cfg.FlushInterval <= 0 {
+		log.Fatalf("invalid -flush-interval %s: must be positive", cfg.FlushInterval)
+	}
+
+	return cfg
+}
+
+// splitNonEmpty 依 sep 分割字串，並濾除空白項目。
+func splitNonEmpty(s string, sep rune) []string {
+	var out []string
+	start := 0
+	for i, r := range s {
+		if r == sep {
+			if part := s[start:i]; part != "" {
+				out = append(out, part)
+			}
+			start = i + 1
+		}
+	}
+	if part := s[start:]; part != "" {
+		out = append(out, part)
+	}
+	return out
+}
+
+// resolvePath 將客戶端提供的相對檔名解析為 RootDir 底下的絕對路徑，
+// 並確保結果仍落在 RootDir 內，避免 "../" 跳脫目錄。
+func (c Config) resolvePath(name string) (string, bool) {
+	clean := filepath.Clean("/" + name)
+	full := filepath.Join(c.RootDir, clean)
+	rel, err := filepath.Rel(c.RootDir, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return full, true
+}
+
+// isAllowed 檢查檔名是否符合 AllowedGlobs 中任一樣式。
+func (c Config) isAllowed(name string) bool {
+	for _, g := range c.AllowedGlobs {
+		if ok, _ := filepath.Match(g, filepath.Base(name)); ok {
+			return true
+		}
+	}
+	return false
+}
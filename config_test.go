@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestResolvePath(t *testing.T) {
+	cfg := Config{RootDir: "/var/log"}
+
+	full, ok := cfg.resolvePath("app.log")
+	if !ok || full != "/var/log/app.log" {
+		t.Fatalf("resolvePath(%q) = (%q, %v), want (/var/log/app.log, true)", "app.log", full, ok)
+	}
+}
+
+func TestResolvePathClampsTraversal(t *testing.T) {
+	cfg := Config{RootDir: "/var/log"}
+
+	// "../" 會先被 Clean 加上的前導 "/" 吃掉，因此結果仍落在 RootDir 底下，
+	// 而不是真的跳到 RootDir 之外。
+	cases := map[string]string{
+		"../etc/passwd":        "/var/log/etc/passwd",
+		"../../etc/passwd":     "/var/log/etc/passwd",
+		"sub/../../etc/passwd": "/var/log/etc/passwd",
+		"..":                   "/var/log",
+	}
+	for name, want := range cases {
+		full, ok := cfg.resolvePath(name)
+		if !ok {
+			t.Errorf("resolvePath(%q) = not ok, want ok", name)
+			continue
+		}
+		if full != want {
+			t.Errorf("resolvePath(%q) = %q, want %q", name, full, want)
+		}
+	}
+}
+
+func TestIsAllowed(t *testing.T) {
+	cfg := Config{AllowedGlobs: []string{"*.log"}}
+
+	if !cfg.isAllowed("app.log") {
+		t.Errorf("isAllowed(%q) = false, want true", "app.log")
+	}
+	if cfg.isAllowed("app.err") {
+		t.Errorf("isAllowed(%q) = true, want false", "app.err")
+	}
+}
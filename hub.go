@@ -0,0 +1,305 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tail "github.com/nxadm/tail" // 日誌追蹤庫
+)
+
+// Hub 是整個伺服器的中樞：管理每個被追蹤檔案的 fileWorker，
+// 並依參考計數決定該檔案是否還需要被 tail，取代舊版「每個連線自己開一個 tail.Tail」的作法。
+type Hub struct {
+	cfg Config
+
+	// supervisor 在有設定 -supervisor-url 時才會非 nil，讓 attachProgram 能把程式名稱解析成日誌路徑。
+	supervisor *processManager
+
+	mu      sync.Mutex
+	workers map[string]*fileWorker
+
+	listMu      sync.Mutex
+	listCache   filesMessage
+	listCacheAt time.Time
+}
+
+// newHub 建立一個尚未啟動任何 worker 的 Hub。
+func newHub(cfg Config) *Hub {
+	return &Hub{
+		cfg:     cfg,
+		workers: make(map[string]*fileWorker),
+	}
+}
+
+// attach 讓 client 訂閱 file。第一個訂閱者會觸發 fileWorker 啟動；
+// 歷史行會先從 ring buffer 回補給該 client。
+func (h *Hub) attach(c *Client, file string) error {
+	if !h.cfg.isAllowed(file) {
+		return errNotAllowed
+	}
+	full, ok := h.cfg.resolvePath(file)
+	if !ok {
+		return errNotAllowed
+	}
+	return h.attachWorker(c, file, full)
+}
+
+// programKey 把 program/stream 正規化成 attachWorker/detach 共用的 fileWorker key，
+// 預設 stream 為 "stdout"，讓 attachProgram 與 detachProgram 的 key 永遠一致。
+func programKey(program, stream string) string {
+	if stream == "" {
+		stream = "stdout"
+	}
+	return program + ":" + stream
+}
+
+// attachProgram 讓 client 以 supervisord 管理的程式名稱訂閱其 stdout/stderr 日誌，
+// 取代直接指定絕對路徑；key 用 "program:stream" 表示，讓同一程式的兩個串流各自獨立快取與參考計數。
+func (h *Hub) attachProgram(c *Client, program, stream string) error {
+	if h.supervisor == nil {
+		return errSupervisorDisabled
+	}
+	key := programKey(program, stream)
+	full, ok := h.supervisor.logFile(program, stream)
+	if !ok {
+		return errNotAllowed
+	}
+	return h.attachWorker(c, key, full)
+}
+
+// attachWorker 是 attach/attachProgram 共用的實作：依 key 找到或建立 fileWorker，
+// 訂閱 client 並回補 ring buffer 裡的歷史行。
+func (h *Hub) attachWorker(c *Client, key, full string) error {
+	h.mu.Lock()
+	w, exists := h.workers[key]
+	if !exists {
+		var err error
+		w, err = newFileWorker(h, key, full)
+		if err != nil {
+			h.mu.Unlock()
+			return err
+		}
+		h.workers[key] = w
+		go w.run()
+	}
+	w.addSubscriber(c)
+	h.mu.Unlock()
+
+	w.broadcastStats()
+
+	if backlog := w.ring.snapshot(); len(backlog) > 0 {
+		c.deliver(logMessage{Type: "log", File: key, Line: strings.Join(backlog, "\n")})
+	}
+	return nil
+}
+
+// detach 取消 client 對 file 的訂閱，若該檔案已無任何訂閱者則停止 fileWorker。
+func (h *Hub) detach(c *Client, file string) {
+	h.detachKey(c, file)
+}
+
+// detachProgram 取消 client 對以 program/stream attach 的訂閱，key 的組成邏輯與 attachProgram 相同。
+func (h *Hub) detachProgram(c *Client, program, stream string) {
+	h.detachKey(c, programKey(program, stream))
+}
+
+func (h *Hub) detachKey(c *Client, key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	w, ok := h.workers[key]
+	if !ok {
+		return
+	}
+	if w.removeSubscriber(c) {
+		delete(h.workers, key)
+		w.stop()
+	} else {
+		w.broadcastStats()
+	}
+}
+
+// detachAll 在客戶端斷線時，取消它對所有檔案的訂閱。
+func (h *Hub) detachAll(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for file, w := range h.workers {
+		if w.removeSubscriber(c) {
+			delete(h.workers, file)
+			w.stop()
+		} else {
+			w.broadcastStats()
+		}
+	}
+}
+
+// list 回傳 RootDir 底下符合 AllowedGlobs 的檔案列表，並依 ListCacheTTL 快取。
+func (h *Hub) list() filesMessage {
+	h.listMu.Lock()
+	defer h.listMu.Unlock()
+
+	if time.Since(h.listCacheAt) < h.cfg.ListCacheTTL && h.listCache.Files != nil {
+		return h.listCache
+	}
+
+	var files []fileInfo
+	entries, err := os.ReadDir(h.cfg.RootDir)
+	if err != nil {
+		log.Printf("list: failed to read root dir %s: %v", h.cfg.RootDir, err)
+		return filesMessage{Type: "files", Files: files}
+	}
+	for _, e := range entries {
+		if e.IsDir() || !h.cfg.isAllowed(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fileInfo{
+			Name:    e.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime().Unix(),
+		})
+	}
+
+	h.listCache = filesMessage{Type: "files", Files: files}
+	h.listCacheAt = time.Now()
+	return h.listCache
+}
+
+// fileWorker 擁有一個 tail.Tail，負責把讀到的每一行同時寫進 ring buffer
+// 並廣播給目前所有訂閱者，且只要還有至少一個訂閱者就會持續運作。
+type fileWorker struct {
+	hub  *Hub
+	name string // client 端看到的檔名，如 "uwsgi.log"
+	path string // 實際解析後的絕對路徑
+
+	tail *tail.Tail
+	ring *ringBuffer
+
+	mu          sync.Mutex
+	subscribers map[*Client]bool
+}
+
+func newFileWorker(h *Hub, name, path string) (*fileWorker, error) {
+	t, err := tail.TailFile(path, tail.Config{
+		Follow:    true,
+		ReOpen:    true,
+		Poll:      h.cfg.Poll,
+		MustExist: false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &fileWorker{
+		hub:         h,
+		name:        name,
+		path:        path,
+		tail:        t,
+		ring:        newRingBuffer(h.cfg.BacklogLines),
+		subscribers: make(map[*Client]bool),
+	}, nil
+}
+
+func (w *fileWorker) addSubscriber(c *Client) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers[c] = true
+}
+
+// removeSubscriber 移除 c，回傳是否已經沒有任何訂閱者（呼叫端需要停止 worker）。
+func (w *fileWorker) removeSubscriber(c *Client) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.subscribers, c)
+	return len(w.subscribers) == 0
+}
+
+func (w *fileWorker) stop() {
+	if err := w.tail.Stop(); err != nil {
+		log.Printf("fileWorker[%s]: stop error: %v", w.name, err)
+	}
+	w.tail.Cleanup()
+}
+
+// run 讀取 tail 的每一行，並依 FlushInterval/MaxBatchBytes 批次廣播，
+// 取代「每讀到一行就送一個 WebSocket frame」的作法，大幅減少高流量日誌的 frame 數與 CPU 負擔。
+func (w *fileWorker) run() {
+	maxLine := w.hub.cfg.MaxLineSize
+	flushInterval := w.hub.cfg.FlushInterval
+	maxBatchBytes := w.hub.cfg.MaxBatchBytes
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []string
+	var batchBytes int
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		w.broadcastBatch(batch)
+		batch = nil
+		batchBytes = 0
+	}
+
+	for {
+		select {
+		case line, ok := <-w.tail.Lines:
+			if !ok {
+				flush()
+				return
+			}
+			if line.Err != nil {
+				log.Printf("fileWorker[%s]: tail error: %v", w.name, line.Err)
+				continue
+			}
+			text := line.Text
+			if maxLine > 0 && len(text) > maxLine {
+				text = text[:maxLine]
+			}
+			w.ring.push(text)
+			batch = append(batch, text)
+			batchBytes += len(text) + 1 // +1 估算合併時的換行符
+			if maxBatchBytes > 0 && batchBytes >= maxBatchBytes {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// broadcastBatch 把累積的 lines 分送給每個訂閱者；每個 client 會先依自己的 filter/pause 狀態
+// 篩選這批 lines，只有篩選後還有內容才會真的送出一個 frame。
+func (w *fileWorker) broadcastBatch(lines []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for c := range w.subscribers {
+		c.deliverLogBatch(w.name, lines)
+	}
+}
+
+// broadcastStats 在訂閱人數變動時（attach、detach）通知目前所有訂閱者，
+// 讓客戶端可以顯示目前有多少人在看同一個檔案。
+func (w *fileWorker) broadcastStats() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := statsMessage{Type: "stats", File: w.name, Subscribers: len(w.subscribers)}
+	for c := range w.subscribers {
+		c.deliver(stats)
+	}
+}
+
+var (
+	errNotAllowed         = &hubError{"file not allowed"}
+	errSupervisorDisabled = &hubError{"supervisor integration not configured"}
+)
+
+type hubError struct{ msg string }
+
+func (e *hubError) Error() string { return e.msg }
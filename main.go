@@ -3,108 +3,60 @@ package main
 import (
 	"log"
 	"net/http"
-	"os"
-	"time"
 
 	"github.com/gorilla/websocket" // WebSocket 庫
-	tail "github.com/nxadm/tail"  // 日誌追蹤庫
 )
 
-// 設定 WebSocket 升級器
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// 允許所有來源連接，或者你可以根據需求限制特定來源
-		return true
-	},
-}
-
-// wsHandler 處理 WebSocket 連接
-func wsHandler(w http.ResponseWriter, r *http.Request) {
-	var logFilePath string
-	if r.URL.Path == "/ws/uwsgi_log" { // 確保這裡仍然檢查 /ws/uwsgi_log
-		logFilePath = "/var/log/uwsgi/uwsgi.log"
-	} else {
-		// 理論上，Nginx 應該只轉發 /ws/uwsgi_log 到這裡
-		// 但以防萬一，如果路徑不符，還是返回 404
-		http.Error(w, "Invalid log path", http.StatusNotFound) // 使用英文以便於錯誤報告一致
-		return
-	}
-
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("WebSocket upgrade failed: %v", err)
-		return
+// wsHandler 處理 WebSocket 連接：升級連線後交給 Client 處理訂閱、退訂與列表請求，
+// 實際的檔案追蹤由 Hub 底下的 fileWorker 負責，多個客戶端共用同一個 worker。
+// CheckOrigin 依 cfg.AllowedOrigins 設定，非法來源在 Upgrade 階段就會被拒絕（回應 403）。
+func wsHandler(hub *Hub, cfg Config) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:    1024,
+		WriteBufferSize:   1024,
+		CheckOrigin:       newCheckOrigin(cfg),
+		EnableCompression: !cfg.NoCompress,
 	}
-	defer conn.Close()
 
-	log.Printf("Client connected to %s WebSocket", logFilePath)
-
-	// --- WebSocket Ping-Pong 心跳機制 ---
-	go func() {
-		ticker := time.NewTicker(30 * time.Second) // 每 30 秒發送一次 Ping
-		defer ticker.Stop()
-		for range ticker.C {
-			if err := conn.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
-				log.Printf("Failed to send ping to client %s: %v", logFilePath, err)
-				return
-			}
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// gorilla/websocket 在 CheckOrigin 失敗時已經自行回應 403，其餘失敗回應則交給它處理。
+			log.Printf("WebSocket upgrade failed: %v", err)
+			return
 		}
-	}()
-	// --- 心跳機制結束 ---
-
-
-	// 可選：將日誌檔案的現有內容發送給新的客戶端
-	if content, err := os.ReadFile(logFilePath); err == nil {
-		if len(content) > 0 {
-			if err := conn.WriteMessage(websocket.TextMessage, content); err != nil {
-				log.Printf("Failed to send initial log content %s: %v", logFilePath, err)
+		if !cfg.NoCompress {
+			conn.EnableWriteCompression(true)
+			if err := conn.SetCompressionLevel(cfg.CompressionLevel); err != nil {
+				log.Printf("WebSocket: invalid compression level %d: %v", cfg.CompressionLevel, err)
 			}
 		}
-	} else {
-		log.Printf("Failed to read initial log content %s: %v", logFilePath, err)
-	}
-
-	// 初始化 tail 追蹤器
-	t, err := tail.TailFile(logFilePath, tail.Config{
-		Follow:    true,
-		ReOpen:    true,
-		Poll:      true,
-		MustExist: false,
-	})
-	if err != nil {
-		log.Printf("Failed to tail log file %s: %v", logFilePath, err)
-		return
-	}
-	defer t.Cleanup()
-
-	// 監聽 tail 庫的 Lines 通道
-	for {
-		select {
-		case line, ok := <-t.Lines:
-			if !ok {
-				log.Printf("Tail read channel closed, log file might be deleted or encountered serious issue %s", logFilePath)
-				return
-			}
-			if line.Err != nil {
-				log.Printf("Tail monitoring error for %s: %v", logFilePath, line.Err)
-			}
 
-			if err := conn.WriteMessage(websocket.TextMessage, []byte(line.Text+"\n")); err != nil {
-				log.Printf("WebSocket write error for %s: %v", logFilePath, err)
-				return
-			}
-		case <-r.Context().Done():
-			log.Printf("Client disconnected from %s WebSocket (Context Done)", logFilePath)
-			return
-		}
+		log.Printf("Client connected from %s", r.RemoteAddr)
+		client := newClient(hub, conn)
+		client.serve()
 	}
 }
 
 func main() {
-	// 設置 HTTP 服務，將 /ws/uwsgi_log 路徑映射到 wsHandler
-	http.HandleFunc("/ws/uwsgi_log", wsHandler)
+	cfg := parseFlags()
+	hub := newHub(cfg)
+
+	// 設置 HTTP 服務，統一由 /ws 這個端點處理所有檔案的 attach/detach/list
+	// 若有設定 Basic Auth 帳密，requireBasicAuth 會在交給 wsHandler（也就是真正的 Upgrade）之前先驗證。
+	http.HandleFunc("/ws", requireBasicAuth(cfg, wsHandler(hub, cfg)))
+
+	// 有設定 -supervisor-url 時才啟用 supervisor 整合：讓 Hub 可以用程式名稱 attach 日誌，
+	// 並開放 /api/processes 系列端點，日誌檢視器因此也能兼作輕量的 process 控制面板。
+	if cfg.SupervisorURL != "" {
+		pm := newProcessManager(cfg.SupervisorURL, cfg.SupervisorRefresh)
+		go pm.run()
+		hub.supervisor = pm
+
+		http.HandleFunc("/api/processes", requireBasicAuth(cfg, processesHandler(pm)))
+		http.HandleFunc("/api/processes/start", requireBasicAuth(cfg, processControlHandler(pm.start)))
+		http.HandleFunc("/api/processes/stop", requireBasicAuth(cfg, processControlHandler(pm.stop)))
+	}
 
 	// ====== 這是為了解決直接訪問 /logs/ (對應 Go 應用程式的 /) 404 問題的最小改動 ======
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -114,15 +66,21 @@ func main() {
 			return
 		}
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("Log viewer backend is running. Connect to /ws/uwsgi_log via WebSocket for logs."))
+		w.Write([]byte("Log viewer backend is running. Connect to /ws via WebSocket and send {\"type\":\"attach\",\"file\":\"...\"} to start tailing."))
 	})
 	// ====================================================================================
 
-	// 啟動 HTTP 服務
-	port := ":1111" // Go 應用程式監聽的端口
-	log.Printf("Go Log Viewer Server listening on %s for uWSGI log", port)
-	err := http.ListenAndServe(port, nil)
-	if err != nil {
+	// 啟動 HTTP 服務：同時指定 -tls-cert 與 -tls-key 時改用 TLS，客戶端需以 wss:// 連線。
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		log.Printf("Go Log Viewer Server listening on %s with TLS (root=%s)", cfg.Addr, cfg.RootDir)
+		if err := http.ListenAndServeTLS(cfg.Addr, cfg.TLSCertFile, cfg.TLSKeyFile, nil); err != nil {
+			log.Fatalf("Failed to start TLS server: %v", err)
+		}
+		return
+	}
+
+	log.Printf("Go Log Viewer Server listening on %s (root=%s)", cfg.Addr, cfg.RootDir)
+	if err := http.ListenAndServe(cfg.Addr, nil); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/echochio-tw/golang-to_view_log/supervisor"
+)
+
+// processManager 在背景定期呼叫 supervisord 的 XML-RPC 介面，
+// 快取目前受管理的程式清單，讓 Hub 可以用程式名稱（而非絕對路徑）來 attach 日誌，
+// 也讓 /api/processes 不必每次請求都打一次 XML-RPC。
+type processManager struct {
+	client       *supervisor.Client
+	refreshEvery time.Duration
+
+	mu        sync.RWMutex
+	processes map[string]supervisor.ProcessInfo
+}
+
+func newProcessManager(url string, refreshEvery time.Duration) *processManager {
+	return &processManager{
+		client:       supervisor.NewClient(url),
+		refreshEvery: refreshEvery,
+		processes:    make(map[string]supervisor.ProcessInfo),
+	}
+}
+
+// run 立刻刷新一次，之後每 refreshEvery 再刷新，直到程式結束為止；設計為以 goroutine 執行。
+func (m *processManager) run() {
+	m.refresh()
+	ticker := time.NewTicker(m.refreshEvery)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.refresh()
+	}
+}
+
+func (m *processManager) refresh() {
+	infos, err := m.client.GetAllProcessInfo()
+	if err != nil {
+		log.Printf("processManager: refresh failed: %v", err)
+		return
+	}
+	next := make(map[string]supervisor.ProcessInfo, len(infos))
+	for _, info := range infos {
+		next[info.Name] = info
+	}
+	m.mu.Lock()
+	m.processes = next
+	m.mu.Unlock()
+}
+
+// list 回傳目前快取的程式清單，依名稱排序以得到穩定的輸出。
+func (m *processManager) list() []supervisor.ProcessInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]supervisor.ProcessInfo, 0, len(m.processes))
+	for _, p := range m.processes {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// logFile 依快取的資料找出 program 在 stream（"stdout"/"stderr"）上的日誌檔路徑。
+func (m *processManager) logFile(program, stream string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.processes[program]
+	if !ok {
+		return "", false
+	}
+	file := p.LogFile(stream)
+	return file, file != ""
+}
+
+// start/stop 呼叫 supervisord 的控制端點，並在之後立刻刷新快取，讓 /api/processes 馬上反映最新狀態。
+func (m *processManager) start(name string) error {
+	err := m.client.StartProcess(name)
+	m.refresh()
+	return err
+}
+
+func (m *processManager) stop(name string) error {
+	err := m.client.StopProcess(name)
+	m.refresh()
+	return err
+}
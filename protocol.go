@@ -0,0 +1,59 @@
+package main
+
+import "encoding/json"
+
+// 以下為客戶端與伺服器之間交換的 JSON 訊息格式。
+// 客戶端 -> 伺服器："attach"、"detach"、"list"、"filter"、"pause"、"resume"。
+// 伺服器 -> 客戶端："log"、"stats"、"files"、"error"。
+
+// clientMessage 是由客戶端送來的任一訊息，先解析 Type 再依需要解析其餘欄位。
+type clientMessage struct {
+	Type    string `json:"type"`
+	File    string `json:"file,omitempty"`
+	Regex   string `json:"regex,omitempty"`
+	Program string `json:"program,omitempty"`
+	Stream  string `json:"stream,omitempty"`
+}
+
+// fileInfo 描述一個可供追蹤的日誌檔案。
+type fileInfo struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+}
+
+// filesMessage 是 "list" 請求的回應，內容會依 ListCacheTTL 快取。
+type filesMessage struct {
+	Type  string     `json:"type"`
+	Files []fileInfo `json:"files"`
+}
+
+// logMessage 送往已訂閱該檔案的客戶端。Line 通常是單行日誌，但批次廣播時
+// 可能包含多行，以 "\n" 分隔，客戶端需再依換行切割還原成個別行。
+type logMessage struct {
+	Type string `json:"type"`
+	File string `json:"file"`
+	Line string `json:"line"`
+}
+
+// statsMessage 回報某個被追蹤檔案目前的訂閱人數，方便除錯與監控。
+type statsMessage struct {
+	Type        string `json:"type"`
+	File        string `json:"file"`
+	Subscribers int    `json:"subscribers"`
+}
+
+// errorMessage 用於回報錯誤，例如訂閱了不被允許的檔案。
+type errorMessage struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func mustMarshal(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		// 這裡的型別都是我們自己定義的，理論上不會序列化失敗。
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,57 @@
+package main
+
+import "sync"
+
+// ringBuffer 保存最新的 N 行日誌，供新訂閱的客戶端回補歷史，
+// 取代舊版直接 os.ReadFile 整個檔案的作法。
+//
+// push 由 fileWorker.run() 這個 goroutine呼叫，snapshot 則由處理 attach 的
+// goroutine（每個 client 各自獨立）呼叫，兩者必定並行發生，因此需要 mu 保護。
+type ringBuffer struct {
+	mu sync.Mutex
+
+	lines []string
+	cap   int
+	next  int
+	full  bool
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{
+		lines: make([]string, capacity),
+		cap:   capacity,
+	}
+}
+
+// push 加入一行新的日誌，滿了之後會覆蓋最舊的一行。
+func (r *ringBuffer) push(line string) {
+	if r.cap == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % r.cap
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot 依寫入順序回傳目前緩衝區中的所有行。
+func (r *ringBuffer) snapshot() []string {
+	if r.cap == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		out := make([]string, r.next)
+		copy(out, r.lines[:r.next])
+		return out
+	}
+	out := make([]string, r.cap)
+	copy(out, r.lines[r.next:])
+	copy(out[r.cap-r.next:], r.lines[:r.next])
+	return out
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRingBufferSnapshotBeforeFull(t *testing.T) {
+	r := newRingBuffer(3)
+	r.push("a")
+	r.push("b")
+
+	got := r.snapshot()
+	want := []string{"a", "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("snapshot() = %v, want %v", got, want)
+	}
+}
+
+func TestRingBufferSnapshotWrapsAround(t *testing.T) {
+	r := newRingBuffer(3)
+	for _, line := range []string{"a", "b", "c", "d", "e"} {
+		r.push(line)
+	}
+
+	got := r.snapshot()
+	want := []string{"c", "d", "e"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("snapshot() = %v, want %v", got, want)
+	}
+}
+
+func TestRingBufferZeroCapacity(t *testing.T) {
+	r := newRingBuffer(0)
+	r.push("a")
+
+	if got := r.snapshot(); got != nil {
+		t.Fatalf("snapshot() = %v, want nil", got)
+	}
+}
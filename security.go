@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// authConfigFile 是 -auth-config 指向的 YAML 檔案格式，只支援單一帳號，
+// 若需要多組帳號可再擴充成陣列。
+//
+//	user: admin
+//	password_hash: <sha256 hex>
+type authConfigFile struct {
+	User         string `yaml:"user"`
+	PasswordHash string `yaml:"password_hash"`
+}
+
+// loadAuthConfig 讀取並解析 YAML 格式的帳號設定檔。
+func loadAuthConfig(path string) (user, passHash string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	var f authConfigFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return "", "", err
+	}
+	return f.User, strings.ToLower(f.PasswordHash), nil
+}
+
+// newCheckOrigin 依 cfg.AllowedOrigins 建立 gorilla/websocket 所需的 CheckOrigin 函式。
+// AllowedOrigins 為空時維持舊版行為（允許所有來源），否則逐一比對，支援 "*.example.com" 萬用字元。
+func newCheckOrigin(cfg Config) func(r *http.Request) bool {
+	if len(cfg.AllowedOrigins) == 0 {
+		return func(r *http.Request) bool { return true }
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return false
+		}
+		for _, pattern := range cfg.AllowedOrigins {
+			if originMatches(pattern, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// originMatches 比對單一 pattern 與 origin，pattern 開頭為 "*." 時視為萬用字元，
+// 比對 origin 的 host（已去除 port）是否為該網域的子網域；網域本身（例如 example.com）不算符合。
+func originMatches(pattern, origin string) bool {
+	if pattern == origin {
+		return true
+	}
+	suffix := strings.TrimPrefix(pattern, "*")
+	if suffix == pattern {
+		return false // pattern 沒有 "*" 前綴，上面已經做過完全比對
+	}
+	return strings.HasSuffix(originHost(origin), suffix)
+}
+
+// originHost 回傳 origin 的 host 部分，去除 scheme 與 port，
+// 例如 "https://app.example.com:3000" 會得到 "app.example.com"。
+func originHost(origin string) string {
+	if u, err := url.Parse(origin); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	host := origin
+	if i := strings.Index(host, "://"); i != -1 {
+		host = host[i+3:]
+	}
+	if i := strings.LastIndex(host, ":"); i != -1 {
+		host = host[:i]
+	}
+	return host
+}
+
+// authEnabled 回報此 Config 是否要求 Basic Auth。
+func (c Config) authEnabled() bool {
+	return c.AuthUser != "" && c.AuthPassHash != ""
+}
+
+// checkBasicAuth 驗證請求的 Authorization 標頭是否符合 cfg 設定的帳密，
+// 密碼以 SHA-256 雜湊後比對，全程使用 constant-time 比較避免時序攻擊洩漏資訊。
+func (c Config) checkBasicAuth(r *http.Request) bool {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	sum := sha256.Sum256([]byte(pass))
+	gotHash := hex.EncodeToString(sum[:])
+
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(c.AuthUser)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(gotHash), []byte(c.AuthPassHash)) == 1
+	return userOK && passOK
+}
+
+// requireBasicAuth 是套在 handler 前面的中介層：驗證失敗時回 401 並附上 WWW-Authenticate，
+// 絕對不會讓請求走到底層的 Upgrade，確保驗證失敗發生在 WebSocket 握手完成之前。
+func requireBasicAuth(cfg Config, next http.HandlerFunc) http.HandlerFunc {
+	if !cfg.authEnabled() {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !cfg.checkBasicAuth(r) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", "to_view_log"))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestOriginMatches(t *testing.T) {
+	cases := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://app.example.com", "https://other.example.com", false},
+		{"*.example.com", "https://app.example.com", true},
+		{"*.example.com", "https://app.example.com:3000", true},
+		{"*.example.com", "https://example.com", false},
+		{"*.example.com", "https://evil.com", false},
+		{"*.example.com", "https://notexample.com", false},
+	}
+	for _, c := range cases {
+		if got := originMatches(c.pattern, c.origin); got != c.want {
+			t.Errorf("originMatches(%q, %q) = %v, want %v", c.pattern, c.origin, got, c.want)
+		}
+	}
+}
+
+func TestOriginHost(t *testing.T) {
+	cases := []struct {
+		origin string
+		want   string
+	}{
+		{"https://app.example.com", "app.example.com"},
+		{"https://app.example.com:3000", "app.example.com"},
+		{"http://localhost:8080", "localhost"},
+		{"app.example.com:3000", "app.example.com"},
+	}
+	for _, c := range cases {
+		if got := originHost(c.origin); got != c.want {
+			t.Errorf("originHost(%q) = %q, want %q", c.origin, got, c.want)
+		}
+	}
+}
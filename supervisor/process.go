@@ -0,0 +1,73 @@
+package supervisor
+
+import "fmt"
+
+// ProcessInfo 對應 supervisor.getAllProcessInfo 回傳的其中一筆資料，
+// 只保留我們用得到的欄位。
+type ProcessInfo struct {
+	Name          string `json:"name"`
+	Group         string `json:"group"`
+	StatusName    string `json:"status"` // 例如 RUNNING、STOPPED、FATAL
+	StdoutLogfile string `json:"stdout_logfile"`
+	StderrLogfile string `json:"stderr_logfile"`
+}
+
+// LogFile 依 stream（"stdout" 或 "stderr"，預設 "stdout"）回傳對應的日誌檔路徑。
+func (p ProcessInfo) LogFile(stream string) string {
+	if stream == "stderr" {
+		return p.StderrLogfile
+	}
+	return p.StdoutLogfile
+}
+
+// GetAllProcessInfo 呼叫 supervisor.getAllProcessInfo，列出所有被 supervisord 管理的程式。
+func (c *Client) GetAllProcessInfo() ([]ProcessInfo, error) {
+	v, err := c.call("supervisor.getAllProcessInfo")
+	if err != nil {
+		return nil, err
+	}
+	if v.Array == nil {
+		return nil, fmt.Errorf("supervisor: getAllProcessInfo: unexpected response shape")
+	}
+
+	infos := make([]ProcessInfo, 0, len(v.Array.Data))
+	for _, item := range v.Array.Data {
+		if item.Struct == nil {
+			continue
+		}
+		infos = append(infos, parseProcessInfo(item.Struct))
+	}
+	return infos, nil
+}
+
+func parseProcessInfo(s *structValue) ProcessInfo {
+	var p ProcessInfo
+	for _, m := range s.Members {
+		switch m.Name {
+		case "name":
+			p.Name = stringOf(m.Value)
+		case "group":
+			p.Group = stringOf(m.Value)
+		case "statename":
+			p.StatusName = stringOf(m.Value)
+		case "stdout_logfile":
+			p.StdoutLogfile = stringOf(m.Value)
+		case "stderr_logfile":
+			p.StderrLogfile = stringOf(m.Value)
+		}
+	}
+	return p
+}
+
+// StartProcess 呼叫 supervisor.startProcess，wait 參數固定傳 true，
+// 也就是等待程式真的進入 RUNNING 狀態（或失敗）才回傳。
+func (c *Client) StartProcess(name string) error {
+	_, err := c.call("supervisor.startProcess", stringValue(name), boolValue(true))
+	return err
+}
+
+// StopProcess 呼叫 supervisor.stopProcess，語意同 StartProcess。
+func (c *Client) StopProcess(name string) error {
+	_, err := c.call("supervisor.stopProcess", stringValue(name), boolValue(true))
+	return err
+}
@@ -0,0 +1,150 @@
+// Package supervisor 提供一個精簡的 XML-RPC 客戶端，只實作與 supervisord 的
+// RPC2 介面互動所需要的部份（enumerate 受管理的程式、啟動/停止程式），
+// 並非通用的 XML-RPC 函式庫。
+package supervisor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Client 是連往單一 supervisord RPC2 端點（例如 http://127.0.0.1:9001/RPC2）的客戶端。
+type Client struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewClient 建立一個 Client，url 通常是 supervisord 設定檔裡 [inet_http_server] 所在位址的 /RPC2。
+func NewClient(url string) *Client {
+	return &Client{
+		url:        url,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// --- 以下為最小夠用的 XML-RPC 資料結構，對應 http://xmlrpc.com/spec.md ---
+
+type methodCall struct {
+	XMLName    xml.Name `xml:"methodCall"`
+	MethodName string   `xml:"methodName"`
+	Params     []param  `xml:"params>param,omitempty"`
+}
+
+type methodResponse struct {
+	XMLName xml.Name    `xml:"methodResponse"`
+	Params  []param     `xml:"params>param"`
+	Fault   *faultValue `xml:"fault"`
+}
+
+type faultValue struct {
+	Value value `xml:"value"`
+}
+
+type param struct {
+	Value value `xml:"value"`
+}
+
+// value 只涵蓋我們實際會送出/解析的型別：string、int、boolean、array、struct。
+type value struct {
+	String  *string      `xml:"string,omitempty"`
+	Int     *int         `xml:"int,omitempty"`
+	Boolean *int         `xml:"boolean,omitempty"`
+	Array   *arrayValue  `xml:"array,omitempty"`
+	Struct  *structValue `xml:"struct,omitempty"`
+}
+
+type arrayValue struct {
+	Data []value `xml:"data>value"`
+}
+
+type structValue struct {
+	Members []member `xml:"member"`
+}
+
+type member struct {
+	Name  string `xml:"name"`
+	Value value  `xml:"value"`
+}
+
+func stringValue(s string) value {
+	return value{String: &s}
+}
+
+func boolValue(b bool) value {
+	i := 0
+	if b {
+		i = 1
+	}
+	return value{Boolean: &i}
+}
+
+func stringOf(v value) string {
+	if v.String != nil {
+		return *v.String
+	}
+	return ""
+}
+
+// call 送出一次 XML-RPC method call，並回傳回應的第一個（也是唯一一個）參數值。
+func (c *Client) call(method string, params ...value) (value, error) {
+	req := methodCall{MethodName: method}
+	for _, p := range params {
+		req.Params = append(req.Params, param{Value: p})
+	}
+
+	body, err := xml.Marshal(req)
+	if err != nil {
+		return value{}, fmt.Errorf("supervisor: marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(append([]byte(xml.Header), body...)))
+	if err != nil {
+		return value{}, fmt.Errorf("supervisor: build request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "text/xml")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return value{}, fmt.Errorf("supervisor: call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return value{}, fmt.Errorf("supervisor: read response: %w", err)
+	}
+
+	var mr methodResponse
+	if err := xml.Unmarshal(data, &mr); err != nil {
+		return value{}, fmt.Errorf("supervisor: unmarshal response: %w", err)
+	}
+	if mr.Fault != nil {
+		return value{}, fmt.Errorf("supervisor: %s faulted: %s", method, faultString(mr.Fault.Value))
+	}
+	if len(mr.Params) == 0 {
+		return value{}, nil
+	}
+	return mr.Params[0].Value, nil
+}
+
+func faultString(v value) string {
+	if v.Struct == nil {
+		return "unknown fault"
+	}
+	var code, msg string
+	for _, m := range v.Struct.Members {
+		switch m.Name {
+		case "faultCode":
+			if m.Value.Int != nil {
+				code = fmt.Sprintf("%d", *m.Value.Int)
+			}
+		case "faultString":
+			msg = stringOf(m.Value)
+		}
+	}
+	return code + " " + msg
+}